@@ -0,0 +1,113 @@
+// Package admission provides helpers for serving jsondiff patches from
+// a Kubernetes mutating admission webhook.
+package admission
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/zemberdotnet/jsondiff"
+)
+
+// AdmissionPatch bundles the Patch produced for a mutating webhook
+// response together with the pre-rendered bytes expected by an
+// AdmissionResponse.
+type AdmissionPatch struct {
+	// Patch is the underlying set of RFC 6902 operations.
+	Patch jsondiff.Patch
+
+	// JSONPatch is the base64-encoded, HTML-unescaped JSON encoding
+	// of Patch, ready to assign to AdmissionResponse.Patch alongside
+	// PatchType: "JSONPatch".
+	JSONPatch []byte
+}
+
+// Option configures the behavior of PatchResponse.
+type Option func(*options)
+
+type options struct {
+	sort     bool
+	omitTest bool
+}
+
+// WithSortedOperations orders the operations of the generated patch
+// deterministically: path-lexicographic, with "remove" operations
+// ordered before "add" operations that share a path. This keeps
+// webhook responses stable across runs despite jsondiff's traversal
+// order being otherwise unspecified.
+func WithSortedOperations() Option {
+	return func(o *options) { o.sort = true }
+}
+
+// WithoutTestOperations drops "test" operations from the generated
+// patch. Kubernetes admission does not permit the "test" op type.
+func WithoutTestOperations() Option {
+	return func(o *options) { o.omitTest = true }
+}
+
+// PatchResponse diffs original against mutated and returns the result
+// as an AdmissionPatch suitable for a mutating webhook response.
+func PatchResponse(original, mutated interface{}, opts ...Option) (*AdmissionPatch, error) {
+	cfg := &options{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	origBytes, err := json.Marshal(original)
+	if err != nil {
+		return nil, fmt.Errorf("admission: marshal original: %w", err)
+	}
+	mutBytes, err := json.Marshal(mutated)
+	if err != nil {
+		return nil, fmt.Errorf("admission: marshal mutated: %w", err)
+	}
+
+	patch, err := jsondiff.CompareJSON(origBytes, mutBytes)
+	if err != nil {
+		return nil, fmt.Errorf("admission: compare: %w", err)
+	}
+
+	if cfg.omitTest {
+		patch = withoutTestOps(patch)
+	}
+	if cfg.sort {
+		sortOperations(patch)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := patch.EncodeWithOptions(buf, false, "", ""); err != nil {
+		return nil, fmt.Errorf("admission: encode patch: %w", err)
+	}
+
+	encoded := make([]byte, base64.StdEncoding.EncodedLen(buf.Len()))
+	base64.StdEncoding.Encode(encoded, buf.Bytes())
+
+	return &AdmissionPatch{
+		Patch:     patch,
+		JSONPatch: encoded,
+	}, nil
+}
+
+func withoutTestOps(patch jsondiff.Patch) jsondiff.Patch {
+	filtered := make(jsondiff.Patch, 0, len(patch))
+	for _, op := range patch {
+		if op.Type == jsondiff.OperationTest {
+			continue
+		}
+		filtered = append(filtered, op)
+	}
+	return filtered
+}
+
+func sortOperations(patch jsondiff.Patch) {
+	sort.SliceStable(patch, func(i, j int) bool {
+		pi, pj := fmt.Sprintf("%s", patch[i].Path), fmt.Sprintf("%s", patch[j].Path)
+		if pi != pj {
+			return pi < pj
+		}
+		return patch[i].Type == jsondiff.OperationRemove && patch[j].Type != jsondiff.OperationRemove
+	})
+}
@@ -0,0 +1,83 @@
+package admission
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/zemberdotnet/jsondiff"
+)
+
+func TestPatchResponse(t *testing.T) {
+	original := map[string]interface{}{"a": 1, "b": 2}
+	mutated := map[string]interface{}{"a": 1, "b": 3}
+
+	resp, err := PatchResponse(original, mutated)
+	if err != nil {
+		t.Fatalf("PatchResponse: %v", err)
+	}
+	if len(resp.Patch) == 0 {
+		t.Fatalf("expected a non-empty patch")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(string(resp.JSONPatch))
+	if err != nil {
+		t.Fatalf("decoding JSONPatch: %v", err)
+	}
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatalf("JSONPatch is not valid JSON: %v", err)
+	}
+	if len(decoded) != len(resp.Patch) {
+		t.Fatalf("JSONPatch has %d ops, Patch has %d", len(decoded), len(resp.Patch))
+	}
+}
+
+func TestPatchResponseWithoutTestOperations(t *testing.T) {
+	patch := jsondiff.Patch{
+		{Type: jsondiff.OperationTest, Path: "/a", Value: 1},
+		{Type: jsondiff.OperationReplace, Path: "/a", Value: 2},
+	}
+	filtered := withoutTestOps(patch)
+	if len(filtered) != 1 || filtered[0].Type != jsondiff.OperationReplace {
+		t.Fatalf("expected only the replace operation to remain, got %v", filtered)
+	}
+}
+
+func TestPatchResponseWithSortedOperations(t *testing.T) {
+	patch := jsondiff.Patch{
+		{Type: jsondiff.OperationAdd, Path: "/b", Value: 1},
+		{Type: jsondiff.OperationAdd, Path: "/a", Value: 1},
+		{Type: jsondiff.OperationRemove, Path: "/a"},
+	}
+	sortOperations(patch)
+
+	want := []struct {
+		path string
+		typ  string
+	}{
+		{"/a", jsondiff.OperationRemove},
+		{"/a", jsondiff.OperationAdd},
+		{"/b", jsondiff.OperationAdd},
+	}
+	for i, w := range want {
+		if string(patch[i].Path) != w.path || patch[i].Type != w.typ {
+			t.Fatalf("op %d = %+v, want path %q type %q", i, patch[i], w.path, w.typ)
+		}
+	}
+}
+
+func TestPatchResponseOptionsEndToEnd(t *testing.T) {
+	original := map[string]interface{}{"a": 1}
+	mutated := map[string]interface{}{"a": 2}
+
+	resp, err := PatchResponse(original, mutated, WithSortedOperations(), WithoutTestOperations())
+	if err != nil {
+		t.Fatalf("PatchResponse: %v", err)
+	}
+	for _, op := range resp.Patch {
+		if op.Type == jsondiff.OperationTest {
+			t.Fatalf("expected no test operations, got %v", resp.Patch)
+		}
+	}
+}
@@ -0,0 +1,429 @@
+package jsondiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ApplyOptions controls the behavior of Patch.ApplyWithOptions.
+type ApplyOptions struct {
+	// SupportNegativeIndices enables the non-standard extension of
+	// resolving negative array indices (e.g. -1) relative to the end
+	// of the array, as supported by several other JSON Patch
+	// implementations.
+	SupportNegativeIndices bool
+
+	// AccumulatedCopySizeLimit bounds the total number of bytes
+	// materialized by "copy" operations across the patch. Applying a
+	// patch that would exceed the limit aborts with an error instead
+	// of continuing, guarding against quadratic blow-up from repeated
+	// copies of large nested structures. A value <= 0 disables the
+	// check.
+	AccumulatedCopySizeLimit int64
+
+	// EnsurePathExistsOnAdd creates any missing intermediate objects
+	// referenced by the path of an "add" operation, similar to
+	// "mkdir -p", instead of failing when a parent does not exist.
+	EnsurePathExistsOnAdd bool
+
+	// AllowMissingPathOnRemove turns a "remove" operation targeting a
+	// path that does not exist into a no-op instead of an error.
+	AllowMissingPathOnRemove bool
+}
+
+// DefaultApplyOptions returns the options used by Patch.Apply.
+func DefaultApplyOptions() *ApplyOptions {
+	return &ApplyOptions{}
+}
+
+// Apply applies the patch to doc and returns the resulting document.
+// It is a shorthand for ApplyWithOptions using the default options.
+func (p Patch) Apply(doc []byte) ([]byte, error) {
+	return p.ApplyWithOptions(doc, DefaultApplyOptions())
+}
+
+// ApplyWithOptions applies the patch to doc according to opts and
+// returns the resulting document. Operations are applied in order
+// against an in-memory copy of doc; if any operation fails, doc is
+// left untouched and an error identifying the failing operation is
+// returned instead of a partially mutated document.
+func (p Patch) ApplyWithOptions(doc []byte, opts *ApplyOptions) ([]byte, error) {
+	if opts == nil {
+		opts = DefaultApplyOptions()
+	}
+
+	var root interface{}
+	if err := json.Unmarshal(doc, &root); err != nil {
+		return nil, fmt.Errorf("jsondiff: invalid document: %w", err)
+	}
+
+	a := &applier{opts: opts}
+	for i, op := range p {
+		updated, err := a.apply(root, op)
+		if err != nil {
+			return nil, fmt.Errorf("jsondiff: operation %d (%s %s): %w", i, op.Type, op.Path, err)
+		}
+		root = updated
+	}
+	return json.Marshal(root)
+}
+
+// applier carries the state needed to apply a Patch: the options in
+// effect and the running total of bytes materialized by "copy" ops.
+type applier struct {
+	opts        *ApplyOptions
+	copiedBytes int64
+}
+
+func (a *applier) apply(root interface{}, op Operation) (interface{}, error) {
+	switch op.Type {
+	case OperationAdd:
+		return a.add(root, op.Path, op.Value)
+	case OperationReplace:
+		return a.replace(root, op.Path, op.Value)
+	case OperationRemove:
+		return a.remove(root, op.Path)
+	case OperationMove:
+		return a.move(root, op.From, op.Path)
+	case OperationCopy:
+		return a.copy(root, op.From, op.Path)
+	case OperationTest:
+		return a.test(root, op.Path, op.Value)
+	default:
+		return nil, fmt.Errorf("unsupported operation type %q", op.Type)
+	}
+}
+
+func (a *applier) add(root interface{}, path pointer, value interface{}) (interface{}, error) {
+	tokens := splitPointer(path)
+	if len(tokens) == 0 {
+		return cloneValue(value), nil
+	}
+	return a.mutate(root, tokens, a.opts.EnsurePathExistsOnAdd, func(parent interface{}, key string) (interface{}, error) {
+		switch n := parent.(type) {
+		case map[string]interface{}:
+			n[key] = cloneValue(value)
+			return n, nil
+		case []interface{}:
+			if key == "-" {
+				return append(n, cloneValue(value)), nil
+			}
+			idx, err := a.resolveIndex(n, key, true)
+			if err != nil {
+				return nil, err
+			}
+			n = append(n, nil)
+			copy(n[idx+1:], n[idx:])
+			n[idx] = cloneValue(value)
+			return n, nil
+		default:
+			return nil, fmt.Errorf("cannot add a child to %T", parent)
+		}
+	})
+}
+
+func (a *applier) replace(root interface{}, path pointer, value interface{}) (interface{}, error) {
+	tokens := splitPointer(path)
+	if len(tokens) == 0 {
+		return cloneValue(value), nil
+	}
+	return a.mutate(root, tokens, false, func(parent interface{}, key string) (interface{}, error) {
+		switch n := parent.(type) {
+		case map[string]interface{}:
+			if _, ok := n[key]; !ok {
+				return nil, fmt.Errorf("key %q does not exist", key)
+			}
+			n[key] = cloneValue(value)
+			return n, nil
+		case []interface{}:
+			idx, err := a.resolveIndex(n, key, false)
+			if err != nil {
+				return nil, err
+			}
+			n[idx] = cloneValue(value)
+			return n, nil
+		default:
+			return nil, fmt.Errorf("cannot replace a child of %T", parent)
+		}
+	})
+}
+
+func (a *applier) remove(root interface{}, path pointer) (interface{}, error) {
+	tokens := splitPointer(path)
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("cannot remove the document root")
+	}
+	updated, err := a.mutateRemove(root, tokens)
+	if err != nil {
+		if a.opts.AllowMissingPathOnRemove {
+			return root, nil
+		}
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (a *applier) move(root interface{}, from, path pointer) (interface{}, error) {
+	value, err := a.get(root, from)
+	if err != nil {
+		return nil, err
+	}
+	root, err = a.remove(root, from)
+	if err != nil {
+		return nil, err
+	}
+	return a.add(root, path, value)
+}
+
+func (a *applier) copy(root interface{}, from, path pointer) (interface{}, error) {
+	value, err := a.get(root, from)
+	if err != nil {
+		return nil, err
+	}
+	if a.opts.AccumulatedCopySizeLimit > 0 {
+		b, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		a.copiedBytes += int64(len(b))
+		if a.copiedBytes > a.opts.AccumulatedCopySizeLimit {
+			return nil, fmt.Errorf("accumulated copy size limit of %d bytes exceeded", a.opts.AccumulatedCopySizeLimit)
+		}
+	}
+	return a.add(root, path, value)
+}
+
+func (a *applier) test(root interface{}, path pointer, value interface{}) (interface{}, error) {
+	actual, err := a.get(root, path)
+	if err != nil {
+		return nil, err
+	}
+	eq, err := canonicalEqual(actual, value)
+	if err != nil {
+		return nil, err
+	}
+	if !eq {
+		return nil, fmt.Errorf("test failed at %q: value mismatch", string(path))
+	}
+	return root, nil
+}
+
+// get resolves path against root and returns the value found there.
+func (a *applier) get(root interface{}, path pointer) (interface{}, error) {
+	cur := root
+	for _, tok := range splitPointer(path) {
+		switch n := cur.(type) {
+		case map[string]interface{}:
+			v, ok := n[tok]
+			if !ok {
+				return nil, fmt.Errorf("key %q does not exist", tok)
+			}
+			cur = v
+		case []interface{}:
+			idx, err := a.resolveIndex(n, tok, false)
+			if err != nil {
+				return nil, err
+			}
+			cur = n[idx]
+		default:
+			return nil, fmt.Errorf("cannot traverse into %T", cur)
+		}
+	}
+	return cur, nil
+}
+
+// mutate walks node along tokens and replaces the value at the final
+// token with the result of fn, returning the (possibly new) root. When
+// ensure is true, missing intermediate objects are created as empty
+// objects rather than causing an error.
+func (a *applier) mutate(node interface{}, tokens []string, ensure bool, fn func(parent interface{}, key string) (interface{}, error)) (interface{}, error) {
+	if len(tokens) == 1 {
+		return fn(node, tokens[0])
+	}
+	key, rest := tokens[0], tokens[1:]
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, ok := n[key]
+		if !ok {
+			if !ensure {
+				return nil, fmt.Errorf("key %q does not exist", key)
+			}
+			child = map[string]interface{}{}
+		}
+		updated, err := a.mutate(child, rest, ensure, fn)
+		if err != nil {
+			return nil, err
+		}
+		n[key] = updated
+		return n, nil
+	case []interface{}:
+		idx, err := a.resolveIndex(n, key, false)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := a.mutate(n[idx], rest, ensure, fn)
+		if err != nil {
+			return nil, err
+		}
+		n[idx] = updated
+		return n, nil
+	default:
+		return nil, fmt.Errorf("cannot traverse into %T", node)
+	}
+}
+
+// mutateRemove is mutate's counterpart for "remove", which deletes the
+// final token instead of replacing it.
+func (a *applier) mutateRemove(node interface{}, tokens []string) (interface{}, error) {
+	if len(tokens) == 1 {
+		key := tokens[0]
+		switch n := node.(type) {
+		case map[string]interface{}:
+			if _, ok := n[key]; !ok {
+				return nil, fmt.Errorf("key %q does not exist", key)
+			}
+			delete(n, key)
+			return n, nil
+		case []interface{}:
+			idx, err := a.resolveIndex(n, key, false)
+			if err != nil {
+				return nil, err
+			}
+			return append(n[:idx], n[idx+1:]...), nil
+		default:
+			return nil, fmt.Errorf("cannot remove a child of %T", node)
+		}
+	}
+	key, rest := tokens[0], tokens[1:]
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, ok := n[key]
+		if !ok {
+			return nil, fmt.Errorf("key %q does not exist", key)
+		}
+		updated, err := a.mutateRemove(child, rest)
+		if err != nil {
+			return nil, err
+		}
+		n[key] = updated
+		return n, nil
+	case []interface{}:
+		idx, err := a.resolveIndex(n, key, false)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := a.mutateRemove(n[idx], rest)
+		if err != nil {
+			return nil, err
+		}
+		n[idx] = updated
+		return n, nil
+	default:
+		return nil, fmt.Errorf("cannot traverse into %T", node)
+	}
+}
+
+// resolveIndex parses an array index token, honoring
+// SupportNegativeIndices. forInsert allows the one-past-the-end index
+// used when inserting (as opposed to reading or removing).
+//
+// The "-" token, normally only meaningful as the target of an "add",
+// is also accepted here to mean the last element of arr. This lets an
+// "add .../-" be undone by a "remove" at the same literal path: by the
+// time Invert's reverse-order undo reaches that removal, every later
+// operation has already been undone, so the array's last element is
+// still exactly the one that add appended.
+func (a *applier) resolveIndex(arr []interface{}, token string, forInsert bool) (int, error) {
+	if token == "-" {
+		if forInsert {
+			return len(arr), nil
+		}
+		if len(arr) == 0 {
+			return 0, fmt.Errorf("array index \"-\" is out of bounds on an empty array")
+		}
+		return len(arr) - 1, nil
+	}
+
+	idx, err := strconv.Atoi(token)
+	if err != nil {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if idx < 0 {
+		if !a.opts.SupportNegativeIndices {
+			return 0, fmt.Errorf("negative array index %q is not supported", token)
+		}
+		// Resolve relative to the end of the array the same way for
+		// every caller, so "-1" means the same element whether it is
+		// being read, replaced, removed, or inserted before.
+		idx += len(arr)
+	}
+	max := len(arr)
+	if !forInsert {
+		max--
+	}
+	if idx < 0 || idx > max {
+		return 0, fmt.Errorf("array index %d is out of bounds", idx)
+	}
+	return idx, nil
+}
+
+// splitPointer decodes a JSON Pointer (RFC 6901) into its unescaped
+// reference tokens.
+func splitPointer(p pointer) []string {
+	s := string(p)
+	if s == "" {
+		return nil
+	}
+	tokens := strings.Split(s[1:], "/")
+	out := make([]string, len(tokens))
+	for i, t := range tokens {
+		out[i] = unescapeToken(t)
+	}
+	return out
+}
+
+func unescapeToken(t string) string {
+	if !strings.ContainsRune(t, '~') {
+		return t
+	}
+	t = strings.ReplaceAll(t, "~1", "/")
+	t = strings.ReplaceAll(t, "~0", "~")
+	return t
+}
+
+// cloneValue deep-copies a decoded JSON value so that inserting it at
+// a new location in the document cannot alias data still referenced
+// elsewhere in the tree.
+func cloneValue(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return v
+	}
+	return out
+}
+
+// canonicalEqual reports whether a and b marshal to the same JSON
+// representation. encoding/json sorts object keys when marshaling
+// map[string]interface{}, which makes this equivalent to a structural
+// deep-equal regardless of original key order.
+func canonicalEqual(a, b interface{}) (bool, error) {
+	ab, err := json.Marshal(a)
+	if err != nil {
+		return false, err
+	}
+	bb, err := json.Marshal(b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(ab, bb), nil
+}
@@ -0,0 +1,127 @@
+package jsondiff
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func mustApply(t *testing.T, doc []byte, p Patch, opts *ApplyOptions) []byte {
+	t.Helper()
+	out, err := p.ApplyWithOptions(doc, opts)
+	if err != nil {
+		t.Fatalf("ApplyWithOptions(%s, %v): %v", doc, p, err)
+	}
+	return out
+}
+
+func assertEqualJSON(t *testing.T, got, want []byte) {
+	t.Helper()
+	eq, err := canonicalEqual(mustDecode(t, got), mustDecode(t, want))
+	if err != nil {
+		t.Fatalf("comparing %s and %s: %v", got, want, err)
+	}
+	if !eq {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+func mustDecode(t *testing.T, b []byte) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		t.Fatalf("decode %s: %v", b, err)
+	}
+	return v
+}
+
+func TestPatchApplyAdd(t *testing.T) {
+	doc := []byte(`{"a":1}`)
+	out := mustApply(t, doc, Patch{{Type: OperationAdd, Path: "/b", Value: 2}}, nil)
+	assertEqualJSON(t, out, []byte(`{"a":1,"b":2}`))
+}
+
+func TestPatchApplyAddArrayAppend(t *testing.T) {
+	doc := []byte(`{"a":[1,2]}`)
+	out := mustApply(t, doc, Patch{{Type: OperationAdd, Path: "/a/-", Value: 3}}, nil)
+	assertEqualJSON(t, out, []byte(`{"a":[1,2,3]}`))
+}
+
+func TestPatchApplyAddEnsurePathExists(t *testing.T) {
+	doc := []byte(`{}`)
+	_, err := Patch{{Type: OperationAdd, Path: "/a/b", Value: 1}}.Apply(doc)
+	if err == nil {
+		t.Fatalf("expected an error adding into a missing parent without EnsurePathExistsOnAdd")
+	}
+
+	out := mustApply(t, doc, Patch{{Type: OperationAdd, Path: "/a/b", Value: 1}}, &ApplyOptions{EnsurePathExistsOnAdd: true})
+	assertEqualJSON(t, out, []byte(`{"a":{"b":1}}`))
+}
+
+func TestPatchApplyReplace(t *testing.T) {
+	doc := []byte(`{"a":1}`)
+	out := mustApply(t, doc, Patch{{Type: OperationReplace, Path: "/a", Value: 2}}, nil)
+	assertEqualJSON(t, out, []byte(`{"a":2}`))
+}
+
+func TestPatchApplyRemove(t *testing.T) {
+	doc := []byte(`{"a":1,"b":2}`)
+	out := mustApply(t, doc, Patch{{Type: OperationRemove, Path: "/a"}}, nil)
+	assertEqualJSON(t, out, []byte(`{"b":2}`))
+}
+
+func TestPatchApplyRemoveAllowMissing(t *testing.T) {
+	doc := []byte(`{"a":1}`)
+	_, err := Patch{{Type: OperationRemove, Path: "/missing"}}.Apply(doc)
+	if err == nil {
+		t.Fatalf("expected an error removing a missing path without AllowMissingPathOnRemove")
+	}
+
+	out := mustApply(t, doc, Patch{{Type: OperationRemove, Path: "/missing"}}, &ApplyOptions{AllowMissingPathOnRemove: true})
+	assertEqualJSON(t, out, doc)
+}
+
+func TestPatchApplyMove(t *testing.T) {
+	doc := []byte(`{"a":1}`)
+	out := mustApply(t, doc, Patch{{Type: OperationMove, From: "/a", Path: "/b"}}, nil)
+	assertEqualJSON(t, out, []byte(`{"b":1}`))
+}
+
+func TestPatchApplyCopy(t *testing.T) {
+	doc := []byte(`{"a":1}`)
+	out := mustApply(t, doc, Patch{{Type: OperationCopy, From: "/a", Path: "/b"}}, nil)
+	assertEqualJSON(t, out, []byte(`{"a":1,"b":1}`))
+}
+
+func TestPatchApplyCopyAccumulatedSizeLimit(t *testing.T) {
+	doc := []byte(`{"a":"0123456789"}`)
+	p := Patch{
+		{Type: OperationCopy, From: "/a", Path: "/b"},
+		{Type: OperationCopy, From: "/a", Path: "/c"},
+	}
+	if _, err := p.ApplyWithOptions(doc, &ApplyOptions{AccumulatedCopySizeLimit: 15}); err == nil {
+		t.Fatalf("expected an error exceeding AccumulatedCopySizeLimit")
+	}
+}
+
+func TestPatchApplyTest(t *testing.T) {
+	doc := []byte(`{"a":1}`)
+	if _, err := (Patch{{Type: OperationTest, Path: "/a", Value: 1}}).Apply(doc); err != nil {
+		t.Fatalf("expected test to pass: %v", err)
+	}
+	if _, err := (Patch{{Type: OperationTest, Path: "/a", Value: 2}}).Apply(doc); err == nil {
+		t.Fatalf("expected test to fail")
+	}
+}
+
+func TestPatchApplyNegativeIndex(t *testing.T) {
+	doc := []byte(`["a","b","c"]`)
+	opts := &ApplyOptions{SupportNegativeIndices: true}
+
+	positive := mustApply(t, doc, Patch{{Type: OperationAdd, Path: "/2", Value: "X"}}, nil)
+	negative := mustApply(t, doc, Patch{{Type: OperationAdd, Path: "/-1", Value: "X"}}, opts)
+	assertEqualJSON(t, negative, positive)
+
+	if _, err := (Patch{{Type: OperationAdd, Path: "/-1", Value: "X"}}).Apply(doc); err == nil {
+		t.Fatalf("expected an error using a negative index without SupportNegativeIndices")
+	}
+}
@@ -0,0 +1,49 @@
+package jsondiff
+
+import "fmt"
+
+// Invert returns a patch that, when applied to the document produced
+// by applying p, reproduces the document p was compared from. It
+// relies on the OldValue recorded by Compare alongside "remove" and
+// "replace" operations, so a patch decoded from JSON rather than
+// produced by Compare cannot be inverted. A "remove" or "replace" of a
+// field whose value was JSON null is still invertible: Compare boxes
+// that captured null with the jsonNull sentinel, so it is only a bare,
+// un-boxed nil that signals a missing old value here.
+func (p Patch) Invert() (Patch, error) {
+	inv := make(Patch, len(p))
+	for i, op := range p {
+		j := len(p) - 1 - i
+
+		switch op.Type {
+		case OperationAdd:
+			// op.Path may end in the "/-" append marker, as Compare's
+			// array-growth operations always do. Reusing it verbatim
+			// here is intentional and correct, not an oversight: by
+			// reverse-order undo, every operation after this one has
+			// already been undone by the time this remove runs, so
+			// the array's last element is still the one this add
+			// appended. See resolveIndex in apply.go.
+			inv[j] = Operation{Type: OperationRemove, Path: op.Path}
+		case OperationRemove:
+			if op.OldValue == nil {
+				return nil, fmt.Errorf("jsondiff: cannot invert %q at %q: missing old value", op.Type, op.Path)
+			}
+			inv[j] = Operation{Type: OperationAdd, Path: op.Path, Value: unboxOldValue(op.OldValue)}
+		case OperationReplace:
+			if op.OldValue == nil {
+				return nil, fmt.Errorf("jsondiff: cannot invert %q at %q: missing old value", op.Type, op.Path)
+			}
+			inv[j] = Operation{Type: OperationReplace, Path: op.Path, Value: unboxOldValue(op.OldValue)}
+		case OperationMove:
+			inv[j] = Operation{Type: OperationMove, From: op.Path, Path: op.From}
+		case OperationCopy:
+			inv[j] = Operation{Type: OperationRemove, Path: op.Path}
+		case OperationTest:
+			inv[j] = op
+		default:
+			return nil, fmt.Errorf("jsondiff: cannot invert unsupported operation %q", op.Type)
+		}
+	}
+	return inv, nil
+}
@@ -0,0 +1,109 @@
+package jsondiff
+
+import "testing"
+
+func mustCompare(t *testing.T, src, tgt string) Patch {
+	t.Helper()
+	p, err := CompareJSON([]byte(src), []byte(tgt))
+	if err != nil {
+		t.Fatalf("CompareJSON(%s, %s): %v", src, tgt, err)
+	}
+	return p
+}
+
+func TestInvertRoundTrip(t *testing.T) {
+	cases := []struct {
+		name     string
+		src, tgt string
+	}{
+		{"add field", `{"a":1}`, `{"a":1,"b":2}`},
+		{"remove field", `{"a":1,"b":2}`, `{"a":1}`},
+		{"replace field", `{"a":1}`, `{"a":2}`},
+		{"remove null field", `{"a":null}`, `{}`},
+		{"replace with null", `{"a":1}`, `{"a":null}`},
+		{"array append", `{"a":[1,2]}`, `{"a":[1,2,3]}`},
+		{"array multiple appends", `{"a":[1]}`, `{"a":[1,2,3,4]}`},
+		{"array shrink", `{"a":[1,2,3]}`, `{"a":[1]}`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := mustCompare(t, tc.src, tc.tgt)
+
+			forward, err := p.Apply([]byte(tc.src))
+			if err != nil {
+				t.Fatalf("applying forward patch: %v", err)
+			}
+			assertEqualJSON(t, forward, []byte(tc.tgt))
+
+			inv, err := p.Invert()
+			if err != nil {
+				t.Fatalf("Invert: %v", err)
+			}
+			back, err := inv.Apply(forward)
+			if err != nil {
+				t.Fatalf("applying inverted patch: %v", err)
+			}
+			assertEqualJSON(t, back, []byte(tc.src))
+		})
+	}
+}
+
+func TestInvertMoveAndCopy(t *testing.T) {
+	doc := []byte(`{"a":1}`)
+
+	move := Patch{{Type: OperationMove, From: "/a", Path: "/b"}}
+	moved, err := move.Apply(doc)
+	if err != nil {
+		t.Fatalf("applying move: %v", err)
+	}
+	invMove, err := move.Invert()
+	if err != nil {
+		t.Fatalf("Invert move: %v", err)
+	}
+	back, err := invMove.Apply(moved)
+	if err != nil {
+		t.Fatalf("applying inverted move: %v", err)
+	}
+	assertEqualJSON(t, back, doc)
+
+	cp := Patch{{Type: OperationCopy, From: "/a", Path: "/b"}}
+	copied, err := cp.Apply(doc)
+	if err != nil {
+		t.Fatalf("applying copy: %v", err)
+	}
+	invCopy, err := cp.Invert()
+	if err != nil {
+		t.Fatalf("Invert copy: %v", err)
+	}
+	back, err = invCopy.Apply(copied)
+	if err != nil {
+		t.Fatalf("applying inverted copy: %v", err)
+	}
+	assertEqualJSON(t, back, doc)
+}
+
+func TestInvertMissingOldValue(t *testing.T) {
+	p := Patch{{Type: OperationRemove, Path: "/a"}}
+	if _, err := p.Invert(); err == nil {
+		t.Fatalf("expected an error inverting a remove with no captured old value")
+	}
+}
+
+func TestInvertUnsupportedOperation(t *testing.T) {
+	p := Patch{{Type: "bogus", Path: "/a"}}
+	if _, err := p.Invert(); err == nil {
+		t.Fatalf("expected an error inverting an unsupported operation type")
+	}
+}
+
+func TestInvertTest(t *testing.T) {
+	p := Patch{{Type: OperationTest, Path: "/a", Value: 1}}
+	inv, err := p.Invert()
+	if err != nil {
+		t.Fatalf("Invert: %v", err)
+	}
+	if len(inv) != 1 || inv[0].Type != OperationTest {
+		t.Fatalf("expected a single unchanged test operation, got %v", inv)
+	}
+}
@@ -0,0 +1,173 @@
+package jsondiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+)
+
+// Compare returns the Patch required to transform src into tgt.
+func Compare(src, tgt interface{}, opts ...Option) (Patch, error) {
+	cfg := &compareConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	var p Patch
+	if err := diffValues(&p, emptyPtr, src, tgt, cfg); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// CompareJSON decodes src and tgt as JSON documents and returns the
+// Patch required to transform src into tgt. With WithOrderedKeys set,
+// objects are decoded into *OrderedMap so that the key order of the
+// source and target documents drives the order in which sibling
+// operations are emitted, instead of sorted key order.
+func CompareJSON(src, tgt []byte, opts ...Option) (Patch, error) {
+	cfg := &compareConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	s, err := decodeCompareValue(src, cfg)
+	if err != nil {
+		return nil, err
+	}
+	t, err := decodeCompareValue(tgt, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var p Patch
+	if err := diffValues(&p, emptyPtr, s, t, cfg); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func decodeCompareValue(b []byte, cfg *compareConfig) (interface{}, error) {
+	if cfg.orderedKeys {
+		return decodeOrderedValue(json.NewDecoder(bytes.NewReader(b)))
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// diffObject is satisfied by both map[string]interface{} (wrapped in
+// mapView) and *OrderedMap, letting diffValues walk either
+// representation through the same code path.
+type diffObject interface {
+	Keys() []string
+	Get(key string) (interface{}, bool)
+}
+
+// mapView adapts map[string]interface{} to diffObject. Since a plain
+// map has no inherent order, Keys returns them sorted for determinism.
+type mapView map[string]interface{}
+
+func (m mapView) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (m mapView) Get(key string) (interface{}, bool) {
+	v, ok := m[key]
+	return v, ok
+}
+
+func objectView(v interface{}) (diffObject, bool) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		return mapView(t), true
+	case *OrderedMap:
+		return t, true
+	default:
+		return nil, false
+	}
+}
+
+func diffValues(p *Patch, path pointer, src, tgt interface{}, cfg *compareConfig) error {
+	srcObj, srcIsObj := objectView(src)
+	tgtObj, tgtIsObj := objectView(tgt)
+	if srcIsObj && tgtIsObj {
+		return diffObjects(p, path, srcObj, tgtObj, cfg)
+	}
+
+	srcArr, srcIsArr := src.([]interface{})
+	tgtArr, tgtIsArr := tgt.([]interface{})
+	if srcIsArr && tgtIsArr {
+		return diffArrays(p, path, srcArr, tgtArr, cfg)
+	}
+
+	eq, err := canonicalEqual(src, tgt)
+	if err != nil {
+		return err
+	}
+	if !eq {
+		*p = p.append(OperationReplace, emptyPtr, path, boxOldValue(src), tgt)
+	}
+	return nil
+}
+
+func diffObjects(p *Patch, prefix pointer, src, tgt diffObject, cfg *compareConfig) error {
+	tgtKeys := tgt.Keys()
+	tgtSeen := make(map[string]bool, len(tgtKeys))
+	for _, k := range tgtKeys {
+		tgtSeen[k] = true
+	}
+
+	for _, k := range src.Keys() {
+		if tgtSeen[k] {
+			continue
+		}
+		sv, _ := src.Get(k)
+		*p = p.append(OperationRemove, emptyPtr, childPath(prefix, k), boxOldValue(sv), nil)
+	}
+
+	for _, k := range tgtKeys {
+		tv, _ := tgt.Get(k)
+		sv, ok := src.Get(k)
+		if !ok {
+			*p = p.append(OperationAdd, emptyPtr, childPath(prefix, k), nil, tv)
+			continue
+		}
+		if err := diffValues(p, childPath(prefix, k), sv, tv, cfg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func diffArrays(p *Patch, prefix pointer, src, tgt []interface{}, cfg *compareConfig) error {
+	n := len(src)
+	if len(tgt) < n {
+		n = len(tgt)
+	}
+	for i := 0; i < n; i++ {
+		if err := diffValues(p, childPath(prefix, strconv.Itoa(i)), src[i], tgt[i], cfg); err != nil {
+			return err
+		}
+	}
+	for i := len(src) - 1; i >= len(tgt); i-- {
+		*p = p.append(OperationRemove, emptyPtr, childPath(prefix, strconv.Itoa(i)), boxOldValue(src[i]), nil)
+	}
+	for i := len(src); i < len(tgt); i++ {
+		*p = p.append(OperationAdd, emptyPtr, pointer(string(prefix)+"/-"), nil, tgt[i])
+	}
+	return nil
+}
+
+func childPath(prefix pointer, key string) pointer {
+	return prefix + pointer("/"+escapeToken(key))
+}
@@ -0,0 +1,105 @@
+package jsondiff
+
+import "testing"
+
+func opPaths(p Patch) []string {
+	out := make([]string, len(p))
+	for i, op := range p {
+		out[i] = string(op.Path)
+	}
+	return out
+}
+
+func TestCompareJSONObjectsAndArrays(t *testing.T) {
+	src := []byte(`{"a":1,"b":[1,2],"c":{"d":1}}`)
+	tgt := []byte(`{"a":1,"b":[1,2,3],"c":{"d":2},"e":5}`)
+
+	p, err := CompareJSON(src, tgt)
+	if err != nil {
+		t.Fatalf("CompareJSON: %v", err)
+	}
+	out, err := p.Apply(src)
+	if err != nil {
+		t.Fatalf("applying diff: %v", err)
+	}
+	assertEqualJSON(t, out, tgt)
+}
+
+func TestCompareJSONOrderedKeysPreservesSiblingOrder(t *testing.T) {
+	src := []byte(`{"z":1,"a":1}`)
+	tgt := []byte(`{"z":2,"a":2}`)
+
+	p, err := CompareJSON(src, tgt, WithOrderedKeys())
+	if err != nil {
+		t.Fatalf("CompareJSON: %v", err)
+	}
+	if got, want := opPaths(p), []string{"/z", "/a"}; !equalStrings(got, want) {
+		t.Fatalf("operation order = %v, want %v", got, want)
+	}
+}
+
+func TestCompareJSONOrderedKeysWholeObjectAdd(t *testing.T) {
+	src := []byte(`{}`)
+	tgt := []byte(`{"outer":{"z":1,"a":1}}`)
+
+	p, err := CompareJSON(src, tgt, WithOrderedKeys())
+	if err != nil {
+		t.Fatalf("CompareJSON: %v", err)
+	}
+	if len(p) != 1 || p[0].Type != OperationAdd || p[0].Path != "/outer" {
+		t.Fatalf("expected a single whole-object add at /outer, got %v", p)
+	}
+	om, ok := p[0].Value.(*OrderedMap)
+	if !ok {
+		t.Fatalf("expected an *OrderedMap value, got %T", p[0].Value)
+	}
+	if got, want := om.Keys(), []string{"z", "a"}; !equalStrings(got, want) {
+		t.Fatalf("added object key order = %v, want %v", got, want)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestOrderedMapSetGetOverwrite(t *testing.T) {
+	m := NewOrderedMap()
+	m.Set("b", 1)
+	m.Set("a", 2)
+	m.Set("b", 3)
+
+	if got, want := m.Keys(), []string{"b", "a"}; !equalStrings(got, want) {
+		t.Fatalf("Keys() = %v, want %v (overwriting an existing key must not move it)", got, want)
+	}
+	if got, _ := m.Get("b"); got != 3 {
+		t.Fatalf("Get(%q) = %v, want 3", "b", got)
+	}
+	if m.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", m.Len())
+	}
+}
+
+func TestOrderedMapMarshalUnmarshalRoundTrip(t *testing.T) {
+	src := []byte(`{"z":1,"a":{"nested":true},"m":[1,2,3]}`)
+	m := NewOrderedMap()
+	if err := m.UnmarshalJSON(src); err != nil {
+		t.Fatalf("UnmarshalJSON: %v", err)
+	}
+	if got, want := m.Keys(), []string{"z", "a", "m"}; !equalStrings(got, want) {
+		t.Fatalf("Keys() = %v, want %v", got, want)
+	}
+
+	out, err := m.MarshalJSON()
+	if err != nil {
+		t.Fatalf("MarshalJSON: %v", err)
+	}
+	assertEqualJSON(t, out, src)
+}
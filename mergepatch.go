@@ -0,0 +1,248 @@
+package jsondiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CompareMerge produces an RFC 7396 JSON Merge Patch document that
+// transforms src into tgt. Unlike a Patch produced by Compare, a merge
+// patch always replaces arrays and scalars wholesale and can only
+// express deletion with an explicit null, so it cannot distinguish
+// "set this field to null" from "remove this field".
+func CompareMerge(src, tgt []byte) ([]byte, error) {
+	var s, t interface{}
+	if err := json.Unmarshal(src, &s); err != nil {
+		return nil, fmt.Errorf("jsondiff: invalid source document: %w", err)
+	}
+	if err := json.Unmarshal(tgt, &t); err != nil {
+		return nil, fmt.Errorf("jsondiff: invalid target document: %w", err)
+	}
+	diff, err := diffMerge(s, t)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(diff)
+}
+
+// ApplyMerge applies the RFC 7396 JSON Merge Patch patch to doc and
+// returns the resulting document.
+func ApplyMerge(doc, patch []byte) ([]byte, error) {
+	var d, p interface{}
+	if err := json.Unmarshal(doc, &d); err != nil {
+		return nil, fmt.Errorf("jsondiff: invalid document: %w", err)
+	}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, fmt.Errorf("jsondiff: invalid merge patch: %w", err)
+	}
+	return json.Marshal(mergePatch(d, p))
+}
+
+// ToMergePatch converts p into an RFC 7396 JSON Merge Patch document.
+// It returns an error if p contains a "move", "copy" or "test"
+// operation, a path segment addressing an array element (including
+// the "-" append marker), or targets the document root, none of which
+// a merge patch can represent.
+//
+// A JSON Pointer carries no type information, so a path segment is
+// judged to address an array element solely by looking like one (see
+// isArrayIndexToken): a Patch that legitimately targets an object key
+// named "0", "12", etc. is rejected here too, even though such a
+// patch could be expressed as a merge patch. This is a real, if rare,
+// false positive — Compare itself will trigger it when diffing two
+// objects that happen to have purely-numeric keys — not just a risk
+// for hand-built or decoded Patches.
+func (p Patch) ToMergePatch() ([]byte, error) {
+	root := map[string]interface{}{}
+	for _, op := range p {
+		switch op.Type {
+		case OperationAdd, OperationReplace:
+			tokens := splitPointer(op.Path)
+			if len(tokens) == 0 {
+				return nil, fmt.Errorf("jsondiff: cannot express %q of the document root as a merge patch", op.Type)
+			}
+			if err := setMergeValue(root, tokens, cloneValue(op.Value)); err != nil {
+				return nil, err
+			}
+		case OperationRemove:
+			tokens := splitPointer(op.Path)
+			if len(tokens) == 0 {
+				return nil, fmt.Errorf("jsondiff: cannot express removal of the document root as a merge patch")
+			}
+			if err := setMergeValue(root, tokens, nil); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("jsondiff: %q operation cannot be expressed as a merge patch", op.Type)
+		}
+	}
+	return json.Marshal(root)
+}
+
+// FromMergePatch decodes an RFC 7396 JSON Merge Patch document into a
+// Patch of "add" and "remove" operations: a null member becomes a
+// "remove", a non-empty object is expanded member by member, and any
+// other member becomes an "add" at its path. The conversion does not
+// see the target document, so the result differs from the merge patch
+// where that document matters: a "remove" of an absent member and an
+// "add" beneath an absent parent object fail under Apply, and an empty
+// object is added as {} instead of leaving an existing object as it is.
+// The resulting operations carry no OldValue, since a merge patch
+// alone does not identify the values it is replacing.
+func FromMergePatch(mp []byte) (Patch, error) {
+	var root interface{}
+	if err := json.Unmarshal(mp, &root); err != nil {
+		return nil, fmt.Errorf("jsondiff: invalid merge patch: %w", err)
+	}
+	rootMap, ok := root.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("jsondiff: merge patch must be a JSON object")
+	}
+	var p Patch
+	emitMergeOps(&p, "", rootMap)
+	return p, nil
+}
+
+func diffMerge(src, tgt interface{}) (interface{}, error) {
+	srcMap, srcOk := src.(map[string]interface{})
+	tgtMap, tgtOk := tgt.(map[string]interface{})
+	if !srcOk || !tgtOk {
+		return tgt, nil
+	}
+
+	patch := map[string]interface{}{}
+	for k, sv := range srcMap {
+		tv, present := tgtMap[k]
+		if !present {
+			patch[k] = nil
+			continue
+		}
+		eq, err := canonicalEqual(sv, tv)
+		if err != nil {
+			return nil, err
+		}
+		if eq {
+			continue
+		}
+		if _, ok := sv.(map[string]interface{}); ok {
+			if _, ok := tv.(map[string]interface{}); ok {
+				nested, err := diffMerge(sv, tv)
+				if err != nil {
+					return nil, err
+				}
+				patch[k] = nested
+				continue
+			}
+		}
+		patch[k] = tv
+	}
+	for k, tv := range tgtMap {
+		if _, ok := srcMap[k]; !ok {
+			patch[k] = tv
+		}
+	}
+	return patch, nil
+}
+
+func mergePatch(target, patch interface{}) interface{} {
+	patchMap, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetMap, ok := target.(map[string]interface{})
+	if !ok {
+		targetMap = map[string]interface{}{}
+	}
+	for k, v := range patchMap {
+		if v == nil {
+			delete(targetMap, k)
+			continue
+		}
+		targetMap[k] = mergePatch(targetMap[k], v)
+	}
+	return targetMap
+}
+
+func setMergeValue(root map[string]interface{}, tokens []string, value interface{}) error {
+	node := root
+	for i, tok := range tokens {
+		if tok == "-" || isArrayIndexToken(tok) {
+			return fmt.Errorf("jsondiff: array index path segment %q cannot be expressed as a merge patch", tok)
+		}
+		if i == len(tokens)-1 {
+			node[tok] = value
+			return nil
+		}
+		child, ok := node[tok]
+		if !ok {
+			child = map[string]interface{}{}
+			node[tok] = child
+		}
+		childMap, ok := child.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("jsondiff: conflicting merge patch entries at %q", tok)
+		}
+		node = childMap
+	}
+	return nil
+}
+
+// isArrayIndexToken reports whether tok has the form of an RFC 6901
+// array index ("0" or a decimal integer without a leading zero). A
+// 6902 path segment shaped like this addresses an array element,
+// which a merge patch cannot express: merge patch objects always
+// merge by key, never by position.
+//
+// This is a syntactic heuristic, not a type check: a Patch has no
+// record of whether a given segment was resolved against an array or
+// an object, so an object key that happens to look like an array
+// index (e.g. "0") is indistinguishable from one and is also
+// rejected. Telling the two apart for real would mean threading that
+// information from Compare through to here.
+func isArrayIndexToken(tok string) bool {
+	if tok == "" {
+		return false
+	}
+	if tok == "0" {
+		return true
+	}
+	if tok[0] == '0' {
+		return false
+	}
+	for _, r := range tok {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func emitMergeOps(p *Patch, prefix pointer, m map[string]interface{}) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v := m[k]
+		path := prefix + pointer("/"+escapeToken(k))
+		if v == nil {
+			*p = p.append(OperationRemove, emptyPtr, path, nil, nil)
+			continue
+		}
+		if child, ok := v.(map[string]interface{}); ok && len(child) > 0 {
+			emitMergeOps(p, path, child)
+			continue
+		}
+		*p = p.append(OperationAdd, emptyPtr, path, nil, v)
+	}
+}
+
+func escapeToken(tok string) string {
+	tok = strings.ReplaceAll(tok, "~", "~0")
+	tok = strings.ReplaceAll(tok, "/", "~1")
+	return tok
+}
@@ -0,0 +1,83 @@
+package jsondiff
+
+import "testing"
+
+func TestCompareMergeAndApplyMerge(t *testing.T) {
+	src := []byte(`{"a":1,"b":{"c":2,"d":3},"e":4}`)
+	tgt := []byte(`{"a":1,"b":{"c":20},"f":5}`)
+
+	mp, err := CompareMerge(src, tgt)
+	if err != nil {
+		t.Fatalf("CompareMerge: %v", err)
+	}
+
+	out, err := ApplyMerge(src, mp)
+	if err != nil {
+		t.Fatalf("ApplyMerge: %v", err)
+	}
+	assertEqualJSON(t, out, tgt)
+}
+
+func TestToMergePatchAndFromMergePatch(t *testing.T) {
+	p := Patch{
+		{Type: OperationAdd, Path: "/a", Value: 1},
+		{Type: OperationReplace, Path: "/b", Value: 2},
+		{Type: OperationRemove, Path: "/c"},
+	}
+	mp, err := p.ToMergePatch()
+	if err != nil {
+		t.Fatalf("ToMergePatch: %v", err)
+	}
+
+	doc := []byte(`{"b":0,"c":0}`)
+	out, err := ApplyMerge(doc, mp)
+	if err != nil {
+		t.Fatalf("ApplyMerge: %v", err)
+	}
+	assertEqualJSON(t, out, []byte(`{"a":1,"b":2}`))
+
+	decoded, err := FromMergePatch(mp)
+	if err != nil {
+		t.Fatalf("FromMergePatch: %v", err)
+	}
+	out2, err := decoded.Apply(doc)
+	if err != nil {
+		t.Fatalf("applying decoded patch: %v", err)
+	}
+	assertEqualJSON(t, out2, out)
+}
+
+func TestToMergePatchRejectsUnrepresentableOperations(t *testing.T) {
+	cases := []Patch{
+		{{Type: OperationMove, From: "/a", Path: "/b"}},
+		{{Type: OperationCopy, From: "/a", Path: "/b"}},
+		{{Type: OperationTest, Path: "/a", Value: 1}},
+		{{Type: OperationAdd, Path: ""}},
+		{{Type: OperationRemove, Path: ""}},
+		{{Type: OperationAdd, Path: "/items/-", Value: 1}},
+		{{Type: OperationAdd, Path: "/items/0", Value: 1}},
+	}
+	for _, p := range cases {
+		if _, err := p.ToMergePatch(); err == nil {
+			t.Errorf("expected an error converting %v to a merge patch", p)
+		}
+	}
+}
+
+func TestIsArrayIndexToken(t *testing.T) {
+	cases := map[string]bool{
+		"":    false,
+		"0":   true,
+		"1":   true,
+		"12":  true,
+		"01":  false,
+		"-1":  false,
+		"abc": false,
+		"-":   false,
+	}
+	for tok, want := range cases {
+		if got := isArrayIndexToken(tok); got != want {
+			t.Errorf("isArrayIndexToken(%q) = %v, want %v", tok, got, want)
+		}
+	}
+}
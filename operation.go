@@ -1,7 +1,6 @@
 package jsondiff
 
 import (
-	"bytes"
 	"encoding/json"
 	"io"
 	"strings"
@@ -20,13 +19,44 @@ const (
 
 // Operation represents a RFC6902 JSON Patch operation.
 type Operation struct {
-	Type     string      `json:"op"`
-	From     pointer     `json:"from,omitempty"`
-	Path     pointer     `json:"path"`
+	Type string  `json:"op"`
+	From pointer `json:"from,omitempty"`
+	Path pointer `json:"path"`
+	// OldValue records the value a "remove" or "replace" operation
+	// overwrote, as captured by Compare, so that Invert can restore
+	// it. A bare nil means no old value was captured at all (e.g. an
+	// Operation decoded from JSON rather than produced by Compare);
+	// a captured JSON null is represented by the jsonNull sentinel so
+	// the two cases remain distinguishable.
 	OldValue interface{} `json:"-"`
 	Value    interface{} `json:"value,omitempty"`
 }
 
+// jsonNull stands in for a captured JSON null in Operation.OldValue,
+// keeping "the old value was null" distinguishable from "no old value
+// was captured" even though both collapse to Go's nil interface value
+// once unboxed.
+type jsonNull struct{}
+
+// boxOldValue prepares a value captured during diffing for storage in
+// Operation.OldValue, translating a captured JSON null (a nil
+// interface{}) into the jsonNull sentinel.
+func boxOldValue(v interface{}) interface{} {
+	if v == nil {
+		return jsonNull{}
+	}
+	return v
+}
+
+// unboxOldValue reverses boxOldValue, returning the plain Go value —
+// nil for a captured JSON null — that was originally boxed.
+func unboxOldValue(v interface{}) interface{} {
+	if _, ok := v.(jsonNull); ok {
+		return nil
+	}
+	return v
+}
+
 // String implements the fmt.Stringer interface.
 func (o Operation) String() string {
 	b, err := json.Marshal(o)
@@ -78,40 +108,17 @@ func (p *Patch) append(typ string, from, path pointer, src, tgt interface{}) Pat
 	})
 }
 
-// Encode with options wraps json.Encode
+// EncodeWithOptions writes p to w as a single JSON array, honoring
+// escapeHTML and the given indentation. It is a thin wrapper around
+// PatchEncoder for callers that already hold the whole Patch in
+// memory; use NewPatchEncoder directly to stream operations without
+// materializing a Patch.
 func (p Patch) EncodeWithOptions(w io.Writer, escapeHTML bool, prefix string, indent string) error {
-	encoder := json.NewEncoder(w)
-	encoder.SetIndent(prefix, indent)
-	if escapeHTML {
-		return encoder.Encode(p)
-	} else {
-		up := make(unescapedPatch, 0, len(p))
-		for _, patch := range p {
-			up = append(up, unescapedOperation(patch))
+	enc := NewPatchEncoder(w, WithEscapeHTML(escapeHTML), WithIndent(prefix, indent))
+	for _, op := range p {
+		if err := enc.Write(op); err != nil {
+			return err
 		}
-
-		encoder.SetEscapeHTML(escapeHTML)
-		return encoder.Encode(up)
 	}
-
-}
-
-type unescapedPatch []unescapedOperation
-type unescapedOperation Operation
-
-// MarshallJSON implements the json.Marshaller interface for unescaped operations
-func (uo unescapedOperation) MarshalJSON() ([]byte, error) {
-	buf := new(bytes.Buffer)
-
-	type u unescapedOperation
-	encoder := json.NewEncoder(buf)
-	encoder.SetEscapeHTML(false)
-
-	err := encoder.Encode(u(uo))
-	if err != nil {
-		return nil, err
-	}
-
-	return buf.Bytes(), nil
-
+	return enc.Close()
 }
@@ -0,0 +1,197 @@
+package jsondiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Option configures the behavior of Compare and CompareJSON.
+type Option func(*compareConfig)
+
+type compareConfig struct {
+	orderedKeys bool
+}
+
+// WithOrderedKeys makes CompareJSON decode JSON objects into an
+// order-preserving OrderedMap instead of map[string]interface{}. With
+// this option set, "add" operations for whole objects and the ordering
+// of sibling operations in the resulting Patch follow the key order of
+// the input documents (removals in source order, everything else in
+// target order) rather than sorted keys, which keeps diff output
+// reproducible for configuration formats (Kubernetes manifests,
+// OpenAPI specs) where that order is meaningful. Compare does not
+// decode its arguments, so the option has no effect there; Compare
+// walks any *OrderedMap it is given in its recorded order and any
+// map[string]interface{} in sorted key order.
+func WithOrderedKeys() Option {
+	return func(c *compareConfig) { c.orderedKeys = true }
+}
+
+// OrderedMap is a JSON object decoded with its member order preserved,
+// backed by an insertion-ordered slice of keys alongside an index map
+// for O(1) lookups.
+type OrderedMap struct {
+	keys   []string
+	index  map[string]int
+	values []interface{}
+}
+
+// NewOrderedMap returns an empty OrderedMap.
+func NewOrderedMap() *OrderedMap {
+	return &OrderedMap{index: map[string]int{}}
+}
+
+// Keys returns the object's member names in source order.
+func (m *OrderedMap) Keys() []string {
+	return m.keys
+}
+
+// Len returns the number of members in the object.
+func (m *OrderedMap) Len() int {
+	return len(m.keys)
+}
+
+// Get returns the value associated with key and whether it was
+// present.
+func (m *OrderedMap) Get(key string) (interface{}, bool) {
+	i, ok := m.index[key]
+	if !ok {
+		return nil, false
+	}
+	return m.values[i], true
+}
+
+// Set inserts or updates key. A key seen for the first time is
+// appended, preserving the order in which keys were encountered.
+func (m *OrderedMap) Set(key string, value interface{}) {
+	if m.index == nil {
+		m.index = map[string]int{}
+	}
+	if i, ok := m.index[key]; ok {
+		m.values[i] = value
+		return
+	}
+	m.index[key] = len(m.keys)
+	m.keys = append(m.keys, key)
+	m.values = append(m.values, value)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, decoding a
+// JSON object while recording its member order. Nested objects decode
+// as *OrderedMap and nested arrays as []interface{}, so order is
+// preserved at every depth.
+func (m *OrderedMap) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return fmt.Errorf("jsondiff: cannot decode %s into an OrderedMap", data)
+	}
+
+	*m = OrderedMap{index: map[string]int{}}
+	for dec.More() {
+		keyTok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := keyTok.(string)
+		if !ok {
+			return fmt.Errorf("jsondiff: unexpected object key token %v", keyTok)
+		}
+		value, err := decodeOrderedValue(dec)
+		if err != nil {
+			return err
+		}
+		m.Set(key, value)
+	}
+
+	// Consume the closing '}'.
+	_, err = dec.Token()
+	return err
+}
+
+// MarshalJSON implements the json.Marshaler interface, writing the
+// object back out in its recorded member order.
+func (m *OrderedMap) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return []byte("null"), nil
+	}
+
+	buf := new(bytes.Buffer)
+	buf.WriteByte('{')
+	for i, k := range m.keys {
+		if i != 0 {
+			buf.WriteByte(',')
+		}
+		kb, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(kb)
+		buf.WriteByte(':')
+		vb, err := json.Marshal(m.values[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(vb)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// decodeOrderedValue decodes the next JSON value from dec, recursing
+// into nested objects as *OrderedMap and nested arrays as
+// []interface{}.
+func decodeOrderedValue(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return tok, nil
+	}
+
+	switch delim {
+	case '{':
+		m := &OrderedMap{index: map[string]int{}}
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, ok := keyTok.(string)
+			if !ok {
+				return nil, fmt.Errorf("jsondiff: unexpected object key token %v", keyTok)
+			}
+			val, err := decodeOrderedValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			m.Set(key, val)
+		}
+		if _, err := dec.Token(); err != nil { // consume '}'
+			return nil, err
+		}
+		return m, nil
+	case '[':
+		arr := []interface{}{}
+		for dec.More() {
+			val, err := decodeOrderedValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, val)
+		}
+		if _, err := dec.Token(); err != nil { // consume ']'
+			return nil, err
+		}
+		return arr, nil
+	default:
+		return nil, fmt.Errorf("jsondiff: unexpected delimiter %q", delim)
+	}
+}
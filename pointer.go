@@ -0,0 +1,16 @@
+package jsondiff
+
+// pointer is a JSON Pointer (RFC 6901) identifying a value within a
+// JSON document. It is used throughout the package to address the
+// "from" and "path" of an Operation, and as the path argument threaded
+// through the diff and apply engines.
+type pointer string
+
+// emptyPtr is the pointer to the whole document, i.e. the empty JSON
+// Pointer ("").
+const emptyPtr pointer = ""
+
+// String implements the fmt.Stringer interface.
+func (p pointer) String() string {
+	return string(p)
+}
@@ -0,0 +1,218 @@
+package jsondiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// EncoderOption configures a PatchEncoder.
+type EncoderOption func(*encoderConfig)
+
+type encoderConfig struct {
+	escapeHTML bool
+	prefix     string
+	indent     string
+}
+
+// WithEscapeHTML controls whether the encoder escapes HTML-unsafe
+// characters ('<', '>', '&') in encoded values, mirroring
+// json.Encoder.SetEscapeHTML. It defaults to true.
+func WithEscapeHTML(escape bool) EncoderOption {
+	return func(c *encoderConfig) { c.escapeHTML = escape }
+}
+
+// WithIndent sets the prefix and indent applied to each encoded
+// operation, mirroring json.Encoder.SetIndent.
+func WithIndent(prefix, indent string) EncoderOption {
+	return func(c *encoderConfig) { c.prefix, c.indent = prefix, indent }
+}
+
+// PatchEncoder writes a stream of Operations to an underlying writer
+// as a single JSON array, one operation at a time, without holding the
+// full Patch in memory. Each Write call marshals only the one
+// operation being written (reusing a single scratch buffer across
+// calls) and then copies those bytes to the underlying writer, so no
+// whole-Patch buffering is required even when escapeHTML is disabled
+// or indentation is configured.
+type PatchEncoder struct {
+	w          io.Writer
+	buf        bytes.Buffer
+	enc        *json.Encoder
+	escapeHTML bool
+	pretty     bool
+	itemPrefix string
+	openTok    string
+	sepTok     string
+	closeTok   string
+	wrote      bool
+	closed     bool
+}
+
+// NewPatchEncoder returns a PatchEncoder that writes to w.
+func NewPatchEncoder(w io.Writer, opts ...EncoderOption) *PatchEncoder {
+	cfg := encoderConfig{escapeHTML: true}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	pretty := cfg.prefix != "" || cfg.indent != ""
+
+	e := &PatchEncoder{w: w, escapeHTML: cfg.escapeHTML, pretty: pretty}
+	e.enc = json.NewEncoder(&e.buf)
+	e.enc.SetEscapeHTML(cfg.escapeHTML)
+
+	if pretty {
+		// Each operation is marshaled as if it sat one level deeper
+		// than the array this encoder writes by hand around it, so its
+		// fields land at cfg.prefix+cfg.indent the same way they would
+		// if the whole Patch had been encoded in one MarshalIndent call.
+		e.itemPrefix = cfg.prefix + cfg.indent
+		e.enc.SetIndent(e.itemPrefix, cfg.indent)
+		e.openTok = "[\n" + e.itemPrefix
+		e.sepTok = ",\n" + e.itemPrefix
+		e.closeTok = "\n" + cfg.prefix + "]"
+	} else {
+		e.openTok = "["
+		e.sepTok = ","
+		e.closeTok = "]"
+	}
+
+	return e
+}
+
+// Write encodes op and appends it to the array. Write must not be
+// called after Close.
+func (e *PatchEncoder) Write(op Operation) error {
+	if e.closed {
+		return errors.New("jsondiff: Write called on a closed PatchEncoder")
+	}
+
+	e.buf.Reset()
+
+	// Operation.MarshalJSON always calls the package-level json.Marshal,
+	// so it bakes in HTML escaping regardless of e.enc's own setting.
+	// Route through unescapedOperation, whose MarshalJSON encodes with
+	// HTML escaping disabled, to actually honor escapeHTML=false.
+	var err error
+	if e.escapeHTML {
+		err = e.enc.Encode(op)
+	} else {
+		err = e.enc.Encode(unescapedOperation(op))
+	}
+	if err != nil {
+		return err
+	}
+
+	tok := e.sepTok
+	if !e.wrote {
+		tok = e.openTok
+	}
+	if _, err := io.WriteString(e.w, tok); err != nil {
+		return err
+	}
+	// Encode always appends a trailing newline; drop it so the
+	// separator/closing token written around the next element (or the
+	// array's close) controls line breaks instead of letting the two
+	// collide.
+	if _, err := e.w.Write(bytes.TrimRight(e.buf.Bytes(), "\n")); err != nil {
+		return err
+	}
+	e.wrote = true
+	return nil
+}
+
+// Close terminates the JSON array. It must be called exactly once,
+// after the last Write, before the output is valid JSON. It writes a
+// trailing newline, matching json.Encoder.Encode, which the previous
+// whole-Patch EncodeWithOptions implementation (and still does here)
+// always appends after its output.
+func (e *PatchEncoder) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+
+	if !e.wrote {
+		_, err := io.WriteString(e.w, "[]\n")
+		return err
+	}
+	_, err := io.WriteString(e.w, e.closeTok+"\n")
+	return err
+}
+
+// PatchDecoder reads a stream of Operations from a JSON array without
+// holding the full Patch in memory.
+type PatchDecoder struct {
+	dec     *json.Decoder
+	started bool
+	done    bool
+}
+
+// NewPatchDecoder returns a PatchDecoder that reads from r.
+func NewPatchDecoder(r io.Reader) *PatchDecoder {
+	return &PatchDecoder{dec: json.NewDecoder(r)}
+}
+
+// Next decodes and returns the next operation in the array. It
+// returns io.EOF once the array is exhausted.
+func (d *PatchDecoder) Next() (Operation, error) {
+	if d.done {
+		return Operation{}, io.EOF
+	}
+
+	if !d.started {
+		d.started = true
+		tok, err := d.dec.Token()
+		if err != nil {
+			return Operation{}, err
+		}
+		if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+			return Operation{}, errors.New("jsondiff: expected a JSON array")
+		}
+	}
+
+	if !d.dec.More() {
+		d.done = true
+		// Consume the closing ']' so a reused decoder/reader lands
+		// just past the array.
+		if _, err := d.dec.Token(); err != nil {
+			return Operation{}, err
+		}
+		return Operation{}, io.EOF
+	}
+
+	var op Operation
+	if err := d.dec.Decode(&op); err != nil {
+		return Operation{}, err
+	}
+	return op, nil
+}
+
+// unescapedOperation marshals an Operation with HTML escaping
+// disabled. It exists because Operation.MarshalJSON delegates to the
+// package-level json.Marshal, which escapes unconditionally and so
+// ignores the escapeHTML setting of whatever json.Encoder is encoding
+// it.
+type unescapedOperation Operation
+
+// MarshalJSON implements the json.Marshaler interface.
+func (uo unescapedOperation) MarshalJSON() ([]byte, error) {
+	switch uo.Type {
+	case OperationCopy, OperationMove:
+		uo.Value = nil
+	case OperationAdd, OperationReplace, OperationTest:
+		uo.From = emptyPtr
+	}
+
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+
+	type u unescapedOperation
+	if err := enc.Encode(u(uo)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
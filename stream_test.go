@@ -0,0 +1,125 @@
+package jsondiff
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestPatchEncoderCompact(t *testing.T) {
+	p := Patch{
+		{Type: OperationAdd, Path: "/a", Value: 1},
+		{Type: OperationReplace, Path: "/b", Value: 2},
+	}
+	var buf bytes.Buffer
+	if err := p.EncodeWithOptions(&buf, true, "", ""); err != nil {
+		t.Fatalf("EncodeWithOptions: %v", err)
+	}
+	want := `[{"op":"add","path":"/a","value":1},{"op":"replace","path":"/b","value":2}]` + "\n"
+	if buf.String() != want {
+		t.Fatalf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestPatchEncoderIndented(t *testing.T) {
+	p := Patch{
+		{Type: OperationAdd, Path: "/a", Value: 1},
+		{Type: OperationReplace, Path: "/b", Value: 2},
+	}
+	var buf bytes.Buffer
+	if err := p.EncodeWithOptions(&buf, true, "", "  "); err != nil {
+		t.Fatalf("EncodeWithOptions: %v", err)
+	}
+	want := "[\n" +
+		"  {\n" +
+		"    \"op\": \"add\",\n" +
+		"    \"path\": \"/a\",\n" +
+		"    \"value\": 1\n" +
+		"  },\n" +
+		"  {\n" +
+		"    \"op\": \"replace\",\n" +
+		"    \"path\": \"/b\",\n" +
+		"    \"value\": 2\n" +
+		"  }\n" +
+		"]\n"
+	if buf.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestPatchEncoderEmptyPatchHasTrailingNewline(t *testing.T) {
+	var p Patch
+	var buf bytes.Buffer
+	if err := p.EncodeWithOptions(&buf, true, "", "  "); err != nil {
+		t.Fatalf("EncodeWithOptions: %v", err)
+	}
+	if buf.String() != "[]\n" {
+		t.Fatalf("got %q, want %q", buf.String(), "[]\n")
+	}
+}
+
+func TestPatchEncoderUnescapedHTML(t *testing.T) {
+	p := Patch{{Type: OperationAdd, Path: "/a", Value: "<b>&</b>"}}
+
+	var escaped bytes.Buffer
+	if err := p.EncodeWithOptions(&escaped, true, "", ""); err != nil {
+		t.Fatalf("EncodeWithOptions: %v", err)
+	}
+	if bytes.Contains(escaped.Bytes(), []byte(`<`)) {
+		t.Fatalf("expected escaped HTML with escapeHTML=true, got %s", escaped.String())
+	}
+
+	var unescaped bytes.Buffer
+	if err := p.EncodeWithOptions(&unescaped, false, "", ""); err != nil {
+		t.Fatalf("EncodeWithOptions: %v", err)
+	}
+	if !bytes.Contains(unescaped.Bytes(), []byte(`<b>`)) {
+		t.Fatalf("expected literal <b> with escapeHTML=false, got %s", unescaped.String())
+	}
+}
+
+func TestPatchEncoderWriteAfterClose(t *testing.T) {
+	enc := NewPatchEncoder(&bytes.Buffer{})
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := enc.Write(Operation{Type: OperationAdd, Path: "/a", Value: 1}); err == nil {
+		t.Fatalf("expected an error writing to a closed PatchEncoder")
+	}
+}
+
+func TestPatchDecoderRoundTrip(t *testing.T) {
+	p := Patch{
+		{Type: OperationAdd, Path: "/a", Value: 1},
+		{Type: OperationRemove, Path: "/b"},
+	}
+	var buf bytes.Buffer
+	if err := p.EncodeWithOptions(&buf, true, "", "  "); err != nil {
+		t.Fatalf("EncodeWithOptions: %v", err)
+	}
+
+	dec := NewPatchDecoder(&buf)
+	var got Patch
+	for {
+		op, err := dec.Next()
+		if err != nil {
+			break
+		}
+		got = append(got, op)
+	}
+	if len(got) != len(p) {
+		t.Fatalf("got %d operations, want %d", len(got), len(p))
+	}
+	for i := range p {
+		if got[i].Type != p[i].Type || got[i].Path != p[i].Path {
+			t.Fatalf("op %d = %+v, want %+v", i, got[i], p[i])
+		}
+	}
+}
+
+func TestPatchDecoderEmptyArray(t *testing.T) {
+	dec := NewPatchDecoder(bytes.NewReader([]byte("[]")))
+	if _, err := dec.Next(); err != io.EOF {
+		t.Fatalf("expected io.EOF for an empty array, got %v", err)
+	}
+}